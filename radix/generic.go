@@ -0,0 +1,114 @@
+// Copyright 2014-6 Randall Farmer. All rights reserved.
+
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package radix
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Unsigned is the set of unsigned integer types usable directly as a
+// SortSlice key.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// genericIndex adapts a slice of any element type, plus a parallel slice of
+// precomputed keys, to Interface using direct indexing rather than a
+// hand-written type or reflection.
+type genericIndex[T any] struct {
+	s    []T
+	keys []Key
+	less func(a, b T) bool
+}
+
+func (g *genericIndex[T]) Len() int { return len(g.s) }
+
+func (g *genericIndex[T]) Swap(i, j int) {
+	g.s[i], g.s[j] = g.s[j], g.s[i]
+	g.keys[i], g.keys[j] = g.keys[j], g.keys[i]
+}
+
+func (g *genericIndex[T]) Key(i int) Key { return g.keys[i] }
+
+func (g *genericIndex[T]) Less(i, j int) bool {
+	ki, kj := g.keys[i], g.keys[j]
+	if g.less == nil {
+		return ki < kj
+	}
+	return ki < kj || (ki == kj && g.less(g.s[i], g.s[j]))
+}
+
+// SortSlice sorts s using key to derive an unsigned radix key for each
+// element, without requiring the caller to declare a named type
+// implementing Interface. It sorts directly against s and a cached key per
+// element, via plain slice indexing and a specialized swap, rather than
+// through Interface's Key/Less/Swap method calls, to save the dispatch
+// overhead those add per comparison and swap in the radix and qsort
+// fallback paths.
+func SortSlice[T any, K Unsigned](s []T, key func(T) K) {
+	keys := make([]Key, len(s))
+	for i, v := range s {
+		keys[i] = Key(key(v))
+	}
+	sortDirect(s, keys, nil)
+}
+
+// SortSliceFunc is SortSlice's counterpart for keys that need the full
+// 64-bit Key range or a tie-breaker: key derives the radix key for an
+// element and less, if non-nil, breaks ties between elements with equal
+// keys. Like SortSlice, it sorts s directly rather than through Interface.
+func SortSliceFunc[T any](s []T, key func(T) Key, less func(a, b T) bool) {
+	keys := make([]Key, len(s))
+	for i, v := range s {
+		keys[i] = key(v)
+	}
+	sortDirect(s, keys, less)
+}
+
+// SortSliceStable is like SortSliceFunc but preserves the input order of
+// elements with equal keys that less (if non-nil) does not order either.
+func SortSliceStable[T any](s []T, key func(T) Key, less func(a, b T) bool) {
+	keys := make([]Key, len(s))
+	for i, v := range s {
+		keys[i] = key(v)
+	}
+	sort.Stable(&genericIndex[T]{s: s, keys: keys, less: less})
+}
+
+// GenericInts sorts a slice of any signed integer type in increasing
+// order; the generic counterpart to Ints, Int32s, and Int64s.
+func GenericInts[T ~int | ~int8 | ~int16 | ~int32 | ~int64](a []T) {
+	SortSlice(a, func(v T) Key { return Int64Key(int64(v)) })
+}
+
+// GenericUints sorts a slice of any unsigned integer type in increasing
+// order; the generic counterpart to Uints, Uint32s, and Uint64s.
+func GenericUints[T Unsigned](a []T) {
+	SortSlice(a, func(v T) Key { return Key(v) })
+}
+
+// GenericFloats sorts a slice of float32s or float64s in increasing order,
+// NaNs last; the generic counterpart to Float32s and Float64s.
+func GenericFloats[T ~float32 | ~float64](a []T) {
+	// Widening to float64 is exact and order-preserving for float32, so
+	// one Key func covers both without a type switch (which would panic
+	// on a named float32 type like "type Celsius float32": its dynamic
+	// type is Celsius, not float32, so a case float32 never matches).
+	SortSlice(a, func(v T) Key { return Float64Key(float64(v)) })
+}
+
+// GenericStrings sorts a slice of any string type in increasing order; the
+// generic counterpart to Strings.
+func GenericStrings[T ~string](a []T) {
+	SortSliceFunc(a, func(v T) Key { return stringKey(string(v), 0) }, func(x, y T) bool { return x < y })
+}
+
+// GenericBytes sorts a slice of any []byte type in increasing order; the
+// generic counterpart to Bytes.
+func GenericBytes[T ~[]byte](a []T) {
+	SortSliceFunc(a, func(v T) Key { return bytesKey([]byte(v), 0) }, func(x, y T) bool { return bytes.Compare(x, y) < 0 })
+}
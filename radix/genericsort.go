@@ -0,0 +1,235 @@
+// Copyright 2014-6 Randall Farmer. All rights reserved.
+
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package radix
+
+// sortDirect sorts s and its parallel keys together by direct slice
+// indexing and a plain two-value swap, the generic counterpart to
+// radixSort/qSort that SortSlice and SortSliceFunc use to avoid paying for
+// a Key/Less/Swap method call (through Interface) on every comparison and
+// swap.
+func sortDirect[T any](s []T, keys []Key, less func(a, b T) bool) {
+	l := len(s)
+	if l < qSortCutoff {
+		insertionSortDirect(s, keys, less, 0, l)
+		return
+	}
+	shift := guessInitialShiftDirect(keys, l)
+	radixSortDirect(s, keys, less, shift, 0, l, &[1 << radix]int{})
+}
+
+func lessDirect[T any](s []T, keys []Key, less func(a, b T) bool, i, j int) bool {
+	ki, kj := keys[i], keys[j]
+	if less == nil {
+		return ki < kj
+	}
+	return ki < kj || (ki == kj && less(s[i], s[j]))
+}
+
+func swapDirect[T any](s []T, keys []Key, i, j int) {
+	s[i], s[j] = s[j], s[i]
+	keys[i], keys[j] = keys[j], keys[i]
+}
+
+// insertionSortDirect is qSortDirect's base case for short ranges, and
+// sortDirect/radixSortDirect's fallback for ranges too small for radix
+// partitioning to pay off in the first place. It's O(n^2), so it must
+// never be handed a range whose size isn't bounded by qSortCutoff.
+func insertionSortDirect[T any](s []T, keys []Key, less func(a, b T) bool, a, b int) {
+	for i := a + 1; i < b; i++ {
+		for j := i; j > a && lessDirect(s, keys, less, j, j-1); j-- {
+			swapDirect(s, keys, j, j-1)
+		}
+	}
+}
+
+// qSortDirect is sortDirect's comparison-sort fallback for ranges too
+// large for insertionSortDirect, mirroring qSort's role in radixSort: a
+// median-of-three quicksort, falling back to insertionSortDirect once a
+// partition shrinks to qSortCutoff elements or fewer, with the larger of each pair of
+// partitions eliminated into the loop instead of recursed into, bounding
+// the stack depth to O(log n). Used wherever a range can't be assumed
+// short, such as a whole range or bucket tied on key: radixSortDirect's
+// diff == 0 and shift == 0 cases can hand it an arbitrarily large range,
+// so unlike insertionSortDirect it can't be O(n^2) in the range size.
+func qSortDirect[T any](s []T, keys []Key, less func(a, b T) bool, a, b int) {
+	for b-a > qSortCutoff {
+		mid := a + (b-a)/2
+		end := b - 1
+		if lessDirect(s, keys, less, mid, a) {
+			swapDirect(s, keys, a, mid)
+		}
+		if lessDirect(s, keys, less, end, a) {
+			swapDirect(s, keys, a, end)
+		}
+		if lessDirect(s, keys, less, end, mid) {
+			swapDirect(s, keys, mid, end)
+		}
+		pivot := end - 1
+		swapDirect(s, keys, mid, pivot)
+
+		i, j := a, pivot
+		for {
+			i++
+			for lessDirect(s, keys, less, i, pivot) {
+				i++
+			}
+			j--
+			for j > a && lessDirect(s, keys, less, pivot, j) {
+				j--
+			}
+			if i >= j {
+				break
+			}
+			swapDirect(s, keys, i, j)
+		}
+		swapDirect(s, keys, i, pivot)
+
+		// Recurse into the smaller side, loop into the larger, so the
+		// stack depth this builds up is bounded by O(log n) even when
+		// the split is lopsided.
+		if i-a < b-i {
+			qSortDirect(s, keys, less, a, i)
+			a = i + 1
+		} else {
+			qSortDirect(s, keys, less, i+1, b)
+			b = i
+		}
+	}
+	insertionSortDirect(s, keys, less, a, b)
+}
+
+// guessInitialShiftDirect is guessInitialShift's counterpart for a bare
+// keys slice, used when there's no Interface to call Key through.
+func guessInitialShiftDirect(keys []Key, l int) uint {
+	step := l >> 5
+	if l > 1<<16 {
+		step = l >> 8
+	}
+	if step == 0 {
+		step = 1
+	}
+	min := keys[l-1]
+	max := min
+	for i := 0; i < l; i += step {
+		k := keys[i]
+		if k < min {
+			min = k
+		}
+		if k > max {
+			max = k
+		}
+	}
+	diff := min ^ max
+	log2diff := 0
+	for diff != 0 {
+		log2diff += radix
+		diff >>= radix
+	}
+	shiftGuess := log2diff - radix
+	if shiftGuess < 0 {
+		return 0
+	}
+	return uint(shiftGuess)
+}
+
+// radixSortDirect is radixSort's counterpart for a slice sorted directly
+// (no Interface): same bucketing logic, but reading keys[i] and swapping
+// via swapDirect instead of data.Key(i)/data.Swap(i, j), and falling back
+// to insertionSortDirect for short ranges and qSortDirect for tied ones
+// where radixSort uses qSort.
+func radixSortDirect[T any](s []T, keys []Key, less func(a, b T) bool, shift uint, a, b int, scratch *[1 << radix]int) {
+	if b-a < qSortCutoff {
+		insertionSortDirect(s, keys, less, a, b)
+		return
+	}
+
+	bucketStarts := scratch
+	for i := range bucketStarts {
+		bucketStarts[i] = 0
+	}
+	min := keys[a]
+	max := min
+	for i := a; i < b; i++ {
+		k := keys[i]
+		bucketStarts[(k>>shift)&mask]++
+		if k < min {
+			min = k
+		}
+		if k > max {
+			max = k
+		}
+	}
+
+	diff := min ^ max
+	if diff == 0 {
+		// The whole range ties at this shift, and may be arbitrarily
+		// large (e.g. every key in s is equal): insertionSortDirect
+		// would be O(n^2) here, so fall back to qSortDirect instead.
+		qSortDirect(s, keys, less, a, b)
+		return
+	}
+	if diff>>shift == 0 || diff>>(shift+radix) != 0 {
+		log2diff := 0
+		for diff != 0 {
+			log2diff++
+			diff >>= 1
+		}
+		nextShift := log2diff - radix
+		if nextShift < 0 {
+			nextShift = 0
+		}
+		radixSortDirect(s, keys, less, uint(nextShift), a, b, scratch)
+		return
+	}
+
+	var bucketEnds [1 << radix]int
+	pos := a
+	for i, c := range bucketStarts {
+		bucketStarts[i] = pos
+		pos += c
+		bucketEnds[i] = pos
+	}
+
+	for curBucket, bucketEnd := range bucketEnds {
+		i := bucketStarts[curBucket]
+		for i < bucketEnd {
+			destBucket := (keys[i] >> shift) & mask
+			if destBucket == Key(curBucket) {
+				i++
+				bucketStarts[destBucket]++
+				continue
+			}
+			swapDirect(s, keys, i, bucketStarts[destBucket])
+			bucketStarts[destBucket]++
+		}
+	}
+
+	if shift == 0 {
+		// Buckets here are tied on the full key, and aren't bounded to
+		// qSortCutoff in size, so use qSortDirect rather than risk
+		// insertionSortDirect's O(n^2) on a large one.
+		pos = a
+		for _, end := range bucketEnds {
+			if end > pos+1 {
+				qSortDirect(s, keys, less, pos, end)
+			}
+			pos = end
+		}
+		return
+	}
+
+	nextShift := shift - radix
+	if shift < radix {
+		nextShift = 0
+	}
+	pos = a
+	for _, end := range bucketEnds {
+		if end > pos+1 {
+			radixSortDirect(s, keys, less, nextShift, pos, end, scratch)
+		}
+		pos = end
+	}
+}
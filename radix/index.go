@@ -57,14 +57,21 @@ func SortIndex(data IndexBuilder) {
 	Sort(idx)
 }
 
-// SortBytes sorts a BytesInterface, using temporary space to speed the sort.
+// SortBytes sorts a BytesInterface, using temporary space to speed the
+// sort. When a run of elements shares a long common prefix, it recurses
+// into further passes deeper into the []byte values instead of falling
+// back straight to comparison sort for the whole run.
 func SortBytes(data BytesInterface) {
-	SortIndex(bytesIndexBuilder{data})
+	sortMSD(bytesIndexBuilder{data})
 }
 
-// SortStrings sorts a StringInterface, using temporary space to speed the sort.
+// SortStrings sorts a StringInterface, using temporary space to speed the
+// sort. When a run of elements shares a long common prefix (as with URLs,
+// file paths, or other string data with long shared prefixes), it
+// recurses into further passes deeper into the strings instead of falling
+// back straight to comparison sort for the whole run.
 func SortStrings(data StringInterface) {
-	SortIndex(stringIndexBuilder{data})
+	sortMSD(stringIndexBuilder{data})
 }
 
 // StringInterface describes a collection of data sortable by a string key.
@@ -77,10 +84,14 @@ type stringIndexBuilder struct {
 	StringInterface
 }
 
-func stringKey(s string) Key {
+// stringKey returns the abbreviated key for up to 8 bytes of s starting at
+// byte offset d. A string shorter than d+8 contributes zero bytes past its
+// end, which sorts before any string with real bytes at the same
+// position, as desired, as long as the string holds no literal zero byte.
+func stringKey(s string, d int) Key {
 	k := Key(0)
 	shift := uint(56)
-	for j := 0; j < 8 && j < len(s); j++ {
+	for j := d; j < d+8 && j < len(s); j++ {
 		k ^= Key(s[j]) << shift
 		shift -= 8
 	}
@@ -88,13 +99,27 @@ func stringKey(s string) Key {
 }
 
 func (sib stringIndexBuilder) SetKeys(keys []Key, a int) {
+	sib.setKeysAt(keys, a, 0)
+}
+
+// setKeysAt is SetKeys generalized with a byte offset d, for use in later
+// MSD passes once a bucket of tied prefixes is found. It reports whether
+// any of the strings it keyed had bytes left past d, so the caller knows
+// whether another pass could still turn up a difference.
+func (sib stringIndexBuilder) setKeysAt(keys []Key, a, d int) bool {
 	l := sib.Len()
+	more := false
 	for i := range keys {
 		if i+a == l {
 			break
 		}
-		keys[i] = stringKey(sib.StringAt(i + a))
+		s := sib.StringAt(i + a)
+		if d < len(s) {
+			more = true
+		}
+		keys[i] = stringKey(s, d)
 	}
+	return more
 }
 
 // BytesInterface describes a collection of data sortable by a []byte key.
@@ -107,10 +132,12 @@ type bytesIndexBuilder struct {
 	BytesInterface
 }
 
-func bytesKey(b []byte) Key {
+// bytesKey returns the abbreviated key for up to 8 bytes of b starting at
+// byte offset d; see stringKey.
+func bytesKey(b []byte, d int) Key {
 	k := Key(0)
 	shift := uint(56)
-	for j := 0; j < 8 && j < len(b); j++ {
+	for j := d; j < d+8 && j < len(b); j++ {
 		k ^= Key(b[j]) << shift
 		shift -= 8
 	}
@@ -118,11 +145,157 @@ func bytesKey(b []byte) Key {
 }
 
 func (bib bytesIndexBuilder) SetKeys(keys []Key, a int) {
+	bib.setKeysAt(keys, a, 0)
+}
+
+// setKeysAt is SetKeys generalized with a byte offset d; see
+// stringIndexBuilder.setKeysAt.
+func (bib bytesIndexBuilder) setKeysAt(keys []Key, a, d int) bool {
 	l := bib.Len()
+	more := false
 	for i := range keys {
 		if i+a == l {
 			break
 		}
-		keys[i] = bytesKey(bib.BytesAt(i + a))
+		b := bib.BytesAt(i + a)
+		if d < len(b) {
+			more = true
+		}
+		keys[i] = bytesKey(b, d)
+	}
+	return more
+}
+
+// msdBuilder is implemented by index builders whose keys are abbreviated
+// byte-string prefixes, so sortMSD can ask for another, deeper pass of
+// keys instead of giving up to data.Less on a big tied-prefix bucket.
+type msdBuilder interface {
+	IndexBuilder
+	setKeysAt(keys []Key, a, d int) bool
+}
+
+// sortMSD sorts data using successive 8-byte-wide radix passes, recursing
+// into setKeysAt for another pass at a deeper byte offset, rather than
+// comparison sort, whenever a bucket of tied prefixes is too big for
+// qSort to handle cheaply.
+func sortMSD(data msdBuilder) {
+	l := data.Len()
+	idx := &index{
+		Keys:      make([]Key, l),
+		Interface: data,
+	}
+	data.SetKeys(idx.Keys, 0)
+
+	if l < qSortCutoff {
+		qSort(idx, 0, l)
+		return
+	}
+
+	shift := guessInitialShift(idx, l)
+	radixSortMSD(data, idx, shift, 0, l, 0, &[1 << radix]int{})
+}
+
+// radixSortMSD is radixSort's counterpart for msdBuilders: it is identical
+// except that, on a bucket of tied prefixes too big for qSort, it tries
+// another pass of keys at byte offset d+8 before giving up to qSort.
+func radixSortMSD(data msdBuilder, idx *index, shift uint, a, b, d int, scratch *[1 << radix]int) {
+	if b-a < qSortCutoff {
+		qSort(idx, a, b)
+		return
+	}
+
+	bucketStarts := scratch
+	for i := range bucketStarts {
+		bucketStarts[i] = 0
+	}
+	min := idx.Key(a)
+	max := min
+	for i := a; i < b; i++ {
+		k := idx.Key(i)
+		bucketStarts[(k>>shift)&mask]++
+		if k < min {
+			min = k
+		}
+		if k > max {
+			max = k
+		}
+	}
+
+	diff := min ^ max
+	if diff == 0 {
+		deeper(data, idx, a, b, d, scratch)
+		return
+	}
+	if diff>>shift == 0 || diff>>(shift+radix) != 0 {
+		log2diff := 0
+		for diff != 0 {
+			log2diff++
+			diff >>= 1
+		}
+		nextShift := log2diff - radix
+		if nextShift < 0 {
+			nextShift = 0
+		}
+		radixSortMSD(data, idx, uint(nextShift), a, b, d, scratch)
+		return
+	}
+
+	var bucketEnds [1 << radix]int
+	pos := a
+	for i, c := range bucketStarts {
+		bucketStarts[i] = pos
+		pos += c
+		bucketEnds[i] = pos
+	}
+
+	for curBucket, bucketEnd := range bucketEnds {
+		i := bucketStarts[curBucket]
+		for i < bucketEnd {
+			destBucket := (idx.Key(i) >> shift) & mask
+			if destBucket == Key(curBucket) {
+				i++
+				bucketStarts[destBucket]++
+				continue
+			}
+			idx.Swap(i, bucketStarts[destBucket])
+			bucketStarts[destBucket]++
+		}
+	}
+
+	if shift == 0 {
+		pos = a
+		for _, end := range bucketEnds {
+			if end > pos+1 {
+				deeper(data, idx, pos, end, d, scratch)
+			}
+			pos = end
+		}
+		return
+	}
+
+	nextShift := shift - radix
+	if shift < radix {
+		nextShift = 0
+	}
+	pos = a
+	for _, end := range bucketEnds {
+		if end > pos+1 {
+			radixSortMSD(data, idx, nextShift, pos, end, d, scratch)
+		}
+		pos = end
+	}
+}
+
+// deeper regenerates idx.Keys[a:b] at byte offset d+8 and recurses into
+// another radix pass there, unless none of the items have bytes at or past
+// d+8, in which case the keys at d already covered all their remaining
+// bytes and they are left in their current order, without calling Less.
+func deeper(data msdBuilder, idx *index, a, b, d int, scratch *[1 << radix]int) {
+	if b-a < qSortCutoff {
+		qSort(idx, a, b)
+		return
+	}
+	if more := data.setKeysAt(idx.Keys[a:b], a, d+8); more {
+		radixSortMSD(data, idx, 56, a, b, d+8, scratch)
 	}
 }
@@ -0,0 +1,39 @@
+// Copyright 2014-6 Randall Farmer. All rights reserved.
+
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package radix
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// TestSortStringsDeepPrefix exercises the MSD recursion in sortMSD/
+// radixSortMSD: these strings share a prefix far longer than the 8 bytes
+// the first pass keys on, so getting the order right requires recursing
+// into further passes rather than giving up to comparison sort after one.
+func TestSortStringsDeepPrefix(t *testing.T) {
+	const n = 5000
+	prefix := "https://example.com/a/very/long/shared/path/prefix/"
+	s := make([]string, n)
+	for i := range s {
+		suffixLen := rand.Intn(12)
+		buf := make([]byte, suffixLen)
+		for j := range buf {
+			buf[j] = byte('a' + rand.Intn(4))
+		}
+		s[i] = prefix + string(buf)
+	}
+	want := append([]string(nil), s...)
+	sort.Strings(want)
+
+	SortStrings(StringSlice(s))
+	for i := range s {
+		if s[i] != want[i] {
+			t.Fatalf("mismatch at %d: got %q want %q", i, s[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2014-6 Randall Farmer. All rights reserved.
+
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package radix
+
+import (
+	"runtime"
+	"sync"
+)
+
+// parallelCutoff is the smallest l SortParallel and SortIndexParallel will
+// bother parallelizing; below it, goroutine overhead dominates and they
+// fall back to Sort and SortIndex.
+const parallelCutoff = 1 << 17
+
+var parallelism = runtime.GOMAXPROCS(0)
+
+// SetParallelism sets how many goroutines SortParallel and
+// SortIndexParallel use at once; it defaults to runtime.GOMAXPROCS(0). It
+// isn't safe to call concurrently with a parallel sort.
+func SetParallelism(n int) {
+	if n < 1 {
+		n = 1
+	}
+	parallelism = n
+}
+
+// SortIndexParallel is SortIndex's parallel counterpart: it builds keys
+// and sorts data across multiple goroutines, falling back to SortIndex
+// outright for inputs too small for that to pay off.
+//
+// Because buckets are only ever touched by one goroutine at a time, this
+// is safe as long as data's SetKeys, Key, Less, and Swap are safe to call
+// concurrently from different goroutines as long as each call's indices
+// stay within disjoint ranges; they need no locking of their own, since
+// the parallel sort never calls them with overlapping indices at once.
+func SortIndexParallel(data IndexBuilder) {
+	l := data.Len()
+	if l < parallelCutoff {
+		SortIndex(data)
+		return
+	}
+
+	idx := &index{
+		Keys:      make([]Key, l),
+		Interface: data,
+	}
+	setKeysParallel(data, idx.Keys, l)
+	sortParallel(idx, l)
+}
+
+// SortParallel is Sort's parallel counterpart, for data whose Key is
+// already cheap; see SortIndexParallel for data that needs keys computed
+// once and cached, and for the concurrency requirement this places on
+// data's methods.
+func SortParallel(data Interface) {
+	l := data.Len()
+	if l < parallelCutoff {
+		Sort(data)
+		return
+	}
+	sortParallel(data, l)
+}
+
+// setKeysParallel calls data.SetKeys across parallelism shards at once,
+// each tallying its own slice of keys[a:b]; SetKeys's offset parameter
+// exists largely to make this possible.
+func setKeysParallel(data IndexBuilder, keys []Key, l int) {
+	n := parallelism
+	if n > l {
+		n = l
+	}
+	shard := (l + n - 1) / n
+	var wg sync.WaitGroup
+	for a := 0; a < l; a += shard {
+		b := a + shard
+		if b > l {
+			b = l
+		}
+		wg.Add(1)
+		go func(a, b int) {
+			defer wg.Done()
+			data.SetKeys(keys[a:b], a)
+		}(a, b)
+	}
+	wg.Wait()
+}
+
+// sortParallel runs the top-level bucket count across parallelism shards,
+// then radixSortParallel (with a worker-token pool sized to
+// parallelism-1, since the caller's own goroutine counts as one worker)
+// to scatter and recurse into the resulting buckets.
+func sortParallel(data Interface, l int) {
+	shift := guessInitialShift(data, l)
+	tokens := make(chan struct{}, parallelism-1)
+	scratch := [1 << radix]int{}
+	min, max := countBucketsParallel(data, shift, 0, l, &scratch)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	finishBucketPass(data, shift, 0, l, min, max, &scratch, &wg, tokens)
+	wg.Wait()
+}
+
+// countBucketsParallel is countBuckets' parallel counterpart: it tallies
+// data[a:b] across parallelism shards at once, each into its own private
+// [256]int scratch to avoid contention, then merges the per-shard counts
+// and min/max into bucketStarts. Used only for the top-level count, since
+// sub-bucket counts during recursion already run on their own goroutine.
+func countBucketsParallel(data Interface, shift uint, a, b int, bucketStarts *[1 << radix]int) (min, max Key) {
+	l := b - a
+	n := parallelism
+	if n > l {
+		n = l
+	}
+	shard := (l + n - 1) / n
+
+	type shardResult struct {
+		counts   [1 << radix]int
+		min, max Key
+	}
+	var starts []int
+	for wa := a; wa < b; wa += shard {
+		starts = append(starts, wa)
+	}
+	results := make([]shardResult, len(starts))
+
+	var wg sync.WaitGroup
+	for i, wa := range starts {
+		wb := wa + shard
+		if wb > b {
+			wb = b
+		}
+		wg.Add(1)
+		go func(i, wa, wb int) {
+			defer wg.Done()
+			r := &results[i]
+			r.min = data.Key(wa)
+			r.max = r.min
+			for j := wa; j < wb; j++ {
+				k := data.Key(j)
+				r.counts[(k>>shift)&mask]++
+				if k < r.min {
+					r.min = k
+				}
+				if k > r.max {
+					r.max = k
+				}
+			}
+		}(i, wa, wb)
+	}
+	wg.Wait()
+
+	min = results[0].min
+	max = results[0].max
+	for i, r := range results {
+		for bkt, c := range r.counts {
+			bucketStarts[bkt] += c
+		}
+		if i > 0 {
+			if r.min < min {
+				min = r.min
+			}
+			if r.max > max {
+				max = r.max
+			}
+		}
+	}
+	return min, max
+}
+
+// countBuckets tallies data[a:b] into bucketStarts sequentially; used for
+// every count past the top level, since by then each count already runs
+// on its own goroutine courtesy of spawnOrRun.
+func countBuckets(data Interface, shift uint, a, b int, bucketStarts *[1 << radix]int) (min, max Key) {
+	min = data.Key(a)
+	max = min
+	for i := a; i < b; i++ {
+		k := data.Key(i)
+		bucketStarts[(k>>shift)&mask]++
+		if k < min {
+			min = k
+		}
+		if k > max {
+			max = k
+		}
+	}
+	return min, max
+}
+
+// radixSortParallel is radixSort, except that once buckets are carved out
+// each is handed to spawnOrRun, which may sort it on a new goroutine:
+// buckets are independent after partitioning, so this is where recursive
+// radix sort fans out across goroutines.
+func radixSortParallel(data Interface, shift uint, a, b int, scratch *[1 << radix]int, wg *sync.WaitGroup, tokens chan struct{}) {
+	if b-a < qSortCutoff {
+		qSort(data, a, b)
+		wg.Done()
+		return
+	}
+
+	for i := range scratch {
+		scratch[i] = 0
+	}
+	min, max := countBuckets(data, shift, a, b, scratch)
+	finishBucketPass(data, shift, a, b, min, max, scratch, wg, tokens)
+}
+
+// finishBucketPass takes the bucket counts and min/max already tallied
+// for data[a:b] at shift (by countBuckets or countBucketsParallel) and
+// either re-counts at a better shift, bails to qSort on a tied range, or
+// scatters into buckets and hands each off to spawnOrRun. It calls
+// wg.Done() itself on every path (directly, or via the radixSortParallel
+// call it recounts through), balancing the Add that preceded this
+// bucket's count.
+func finishBucketPass(data Interface, shift uint, a, b int, min, max Key, bucketStarts *[1 << radix]int, wg *sync.WaitGroup, tokens chan struct{}) {
+	diff := min ^ max
+	if diff == 0 {
+		qSort(data, a, b)
+		wg.Done()
+		return
+	}
+	if diff>>shift == 0 || diff>>(shift+radix) != 0 {
+		log2diff := 0
+		for diff != 0 {
+			log2diff++
+			diff >>= 1
+		}
+		nextShift := log2diff - radix
+		if nextShift < 0 {
+			nextShift = 0
+		}
+		// shift guessed wrong: recount at a better one instead of
+		// scattering into what would be one giant bucket.
+		radixSortParallel(data, uint(nextShift), a, b, bucketStarts, wg, tokens)
+		return
+	}
+
+	var bucketEnds [1 << radix]int
+	pos := a
+	for i, c := range bucketStarts {
+		bucketStarts[i] = pos
+		pos += c
+		bucketEnds[i] = pos
+	}
+
+	for curBucket, bucketEnd := range bucketEnds {
+		i := bucketStarts[curBucket]
+		for i < bucketEnd {
+			destBucket := (data.Key(i) >> shift) & mask
+			if destBucket == Key(curBucket) {
+				i++
+				bucketStarts[destBucket]++
+				continue
+			}
+			data.Swap(i, bucketStarts[destBucket])
+			bucketStarts[destBucket]++
+		}
+	}
+
+	nextShift := uint(0)
+	if shift >= radix {
+		nextShift = shift - radix
+	}
+
+	pos = a
+	for _, end := range bucketEnds {
+		if end > pos+1 {
+			spawnOrRun(data, nextShift, pos, end, wg, tokens)
+		}
+		pos = end
+	}
+	wg.Done()
+}
+
+// spawnOrRun sorts data[a:b], using a free worker token to run it on a new
+// goroutine when one is available and the bucket is big enough to be
+// worth it, and running it inline otherwise.
+func spawnOrRun(data Interface, shift uint, a, b int, wg *sync.WaitGroup, tokens chan struct{}) {
+	wg.Add(1)
+	if b-a < qSortCutoff {
+		radixSortParallel(data, shift, a, b, &[1 << radix]int{}, wg, tokens)
+		return
+	}
+	select {
+	case tokens <- struct{}{}:
+		go func() {
+			defer func() { <-tokens }()
+			radixSortParallel(data, shift, a, b, &[1 << radix]int{}, wg, tokens)
+		}()
+	default:
+		radixSortParallel(data, shift, a, b, &[1 << radix]int{}, wg, tokens)
+	}
+}
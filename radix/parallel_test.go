@@ -0,0 +1,69 @@
+// Copyright 2014-6 Randall Farmer. All rights reserved.
+
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package radix
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// TestSortParallel checks SortParallel against sort.Ints, over several
+// random sizes that straddle the top-level parallel bucket count, the
+// recursive per-bucket goroutines, and the worker-token pool in
+// spawnOrRun. Run with -race: correctness here depends on buckets never
+// overlapping between goroutines.
+func TestSortParallel(t *testing.T) {
+	for trial := 0; trial < 5; trial++ {
+		n := parallelCutoff + rand.Intn(parallelCutoff)
+		a := make([]int, n)
+		for i := range a {
+			a[i] = rand.Intn(1 << 24)
+		}
+		want := append([]int(nil), a...)
+		sort.Ints(want)
+
+		SortParallel(IntSlice(a))
+		for i := range a {
+			if a[i] != want[i] {
+				t.Fatalf("trial %d: mismatch at %d: got %d want %d", trial, i, a[i], want[i])
+			}
+		}
+	}
+}
+
+// TestSortIndexParallel is TestSortParallel's counterpart for
+// SortIndexParallel, which additionally exercises setKeysParallel.
+func TestSortIndexParallel(t *testing.T) {
+	const n = parallelCutoff + parallelCutoff/2
+	a := make([]int, n)
+	for i := range a {
+		a[i] = rand.Intn(1 << 24)
+	}
+	want := append([]int(nil), a...)
+	sort.Ints(want)
+
+	SortIndexParallel(intIndexBuilder(a))
+	for i := range a {
+		if a[i] != want[i] {
+			t.Fatalf("mismatch at %d: got %d want %d", i, a[i], want[i])
+		}
+	}
+}
+
+type intIndexBuilder []int
+
+func (p intIndexBuilder) Len() int           { return len(p) }
+func (p intIndexBuilder) Less(i, j int) bool { return p[i] < p[j] }
+func (p intIndexBuilder) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p intIndexBuilder) SetKeys(keys []Key, a int) {
+	for i := range keys {
+		if i+a == len(p) {
+			break
+		}
+		keys[i] = Int64Key(int64(p[i+a]))
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2014-6 Randall Farmer. All rights reserved.
+
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package radix
+
+import "reflect"
+
+// reflectSlice adapts an arbitrary slice value, plus key/less closures
+// indexed the way sort.Slice's are, to Interface using reflection; see
+// indexedSlice for the generic equivalent SortByFunc uses to avoid the
+// reflection cost.
+type reflectSlice struct {
+	l    int
+	swap func(i, j int)
+	key  func(i int) Key
+	less func(i, j int) bool
+}
+
+func (r reflectSlice) Len() int      { return r.l }
+func (r reflectSlice) Swap(i, j int) { r.swap(i, j) }
+func (r reflectSlice) Key(i int) Key { return r.key(i) }
+
+func (r reflectSlice) Less(i, j int) bool {
+	if r.less == nil {
+		return false
+	}
+	return r.less(i, j)
+}
+
+// SortBy sorts slice using key to derive a radix key for each element by
+// index and, if non-nil, less to break ties between elements with equal
+// keys, the way bradfitz/slice's Sort lets callers sort without declaring
+// a named type implementing Interface. slice must be a slice value (not a
+// pointer to one).
+func SortBy(slice interface{}, key func(i int) Key, less func(i, j int) bool) {
+	rv := reflect.ValueOf(slice)
+	Sort(reflectSlice{
+		l:    rv.Len(),
+		swap: reflect.Swapper(slice),
+		key:  key,
+		less: less,
+	})
+}
+
+// indexedSlice adapts a slice and index-based key/less closures to
+// Interface using direct indexing; the generic counterpart to the
+// reflection reflectSlice uses for SortBy.
+type indexedSlice[T any] struct {
+	s    []T
+	key  func(i int) Key
+	less func(i, j int) bool
+}
+
+func (s indexedSlice[T]) Len() int      { return len(s.s) }
+func (s indexedSlice[T]) Swap(i, j int) { s.s[i], s.s[j] = s.s[j], s.s[i] }
+func (s indexedSlice[T]) Key(i int) Key { return s.key(i) }
+
+func (s indexedSlice[T]) Less(i, j int) bool {
+	if s.less == nil {
+		return false
+	}
+	return s.less(i, j)
+}
+
+// SortByFunc is SortBy's generic counterpart: it sorts s using key and
+// less, both indexed like sort.Slice's, with direct indexing in place of
+// reflection.
+func SortByFunc[T any](s []T, key func(i int) Key, less func(i, j int) bool) {
+	Sort(indexedSlice[T]{s: s, key: key, less: less})
+}
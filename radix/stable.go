@@ -0,0 +1,177 @@
+// Copyright 2014-6 Randall Farmer. All rights reserved.
+
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package radix
+
+import "sort"
+
+// indexPerm adapts a permutation of original indices, and their cached
+// keys, to sort.Interface, so sort.Stable can order them without touching
+// data directly; the caller applies the result to data with permute once
+// sorting is done.
+type indexPerm struct {
+	data Interface
+	keys []Key
+	perm []int
+}
+
+func (p *indexPerm) Len() int { return len(p.perm) }
+
+func (p *indexPerm) Less(i, j int) bool {
+	ki, kj := p.keys[i], p.keys[j]
+	return ki < kj || (ki == kj && p.data.Less(p.perm[i], p.perm[j]))
+}
+
+func (p *indexPerm) Swap(i, j int) {
+	p.keys[i], p.keys[j] = p.keys[j], p.keys[i]
+	p.perm[i], p.perm[j] = p.perm[j], p.perm[i]
+}
+
+// permute rearranges data into the order described by perm (perm[i] is the
+// original index of the element that belongs at position i), using
+// data.Swap and following permutation cycles so each element moves into
+// place exactly once.
+func permute(data Interface, perm []int) {
+	// Following the cycles of perm directly with pairwise swaps applies
+	// its inverse (it moves data[i] to where perm says i itself goes,
+	// not where its contents should come from), so invert perm first:
+	// scatter[p] = i for each i says item p's new home is position i.
+	scatter := make([]int, len(perm))
+	for i, p := range perm {
+		scatter[p] = i
+	}
+	for i := range scatter {
+		for scatter[i] != i {
+			j := scatter[i]
+			data.Swap(i, j)
+			scatter[i], scatter[j] = scatter[j], scatter[i]
+		}
+	}
+}
+
+// SortStable sorts data like Sort, but elements with equal keys keep
+// their original relative order. Unlike Sort's in-place partitioning,
+// this builds an O(n) permutation (and caches a Key per element)
+// out-of-place, then applies it to data with a final pass of Swaps, so
+// callers that don't need stability should prefer the cheaper Sort.
+func SortStable(data Interface) {
+	l := data.Len()
+	keys := make([]Key, l)
+	for i := range keys {
+		keys[i] = data.Key(i)
+	}
+	perm := make([]int, l)
+	for i := range perm {
+		perm[i] = i
+	}
+
+	if l < qSortCutoff {
+		sort.Stable(&indexPerm{data: data, keys: keys, perm: perm})
+	} else {
+		shift := guessInitialShift(data, l)
+		radixSortStable(data, keys, perm, shift, 0, l, make([]Key, l), make([]int, l), &[1 << radix]int{})
+	}
+
+	permute(data, perm)
+}
+
+// SortIndexStable is SortIndex's stable counterpart; see SortStable for
+// the memory/time tradeoff stability costs.
+func SortIndexStable(data IndexBuilder) {
+	l := data.Len()
+	keys := make([]Key, l)
+	data.SetKeys(keys, 0)
+	SortStable(&index{Keys: keys, Interface: data})
+}
+
+// radixSortStable is radixSort's stable counterpart. Each pass scatters
+// keys[a:b]/perm[a:b] left to right into the aux buffers at the same
+// offsets, using bucketStarts as per-bucket write cursors, so elements
+// with equal keys keep their relative order; sort.Stable, not qSort,
+// breaks ties on the final pass.
+func radixSortStable(data Interface, keys []Key, perm []int, shift uint, a, b int, auxKeys []Key, auxPerm []int, scratch *[1 << radix]int) {
+	if b-a < qSortCutoff {
+		sort.Stable(&indexPerm{data: data, keys: keys[a:b], perm: perm[a:b]})
+		return
+	}
+
+	bucketStarts := scratch
+	for i := range bucketStarts {
+		bucketStarts[i] = 0
+	}
+	min := keys[a]
+	max := min
+	for i := a; i < b; i++ {
+		k := keys[i]
+		bucketStarts[(k>>shift)&mask]++
+		if k < min {
+			min = k
+		}
+		if k > max {
+			max = k
+		}
+	}
+
+	diff := min ^ max
+	if diff == 0 {
+		sort.Stable(&indexPerm{data: data, keys: keys[a:b], perm: perm[a:b]})
+		return
+	}
+	if diff>>shift == 0 || diff>>(shift+radix) != 0 {
+		log2diff := 0
+		for diff != 0 {
+			log2diff++
+			diff >>= 1
+		}
+		nextShift := log2diff - radix
+		if nextShift < 0 {
+			nextShift = 0
+		}
+		radixSortStable(data, keys, perm, uint(nextShift), a, b, auxKeys, auxPerm, scratch)
+		return
+	}
+
+	var bucketEnds [1 << radix]int
+	pos := a
+	for i, c := range bucketStarts {
+		bucketStarts[i] = pos
+		pos += c
+		bucketEnds[i] = pos
+	}
+
+	cursors := bucketStarts
+	for i := a; i < b; i++ {
+		k := keys[i]
+		dest := cursors[(k>>shift)&mask]
+		auxKeys[dest] = k
+		auxPerm[dest] = perm[i]
+		cursors[(k>>shift)&mask]++
+	}
+	copy(keys[a:b], auxKeys[a:b])
+	copy(perm[a:b], auxPerm[a:b])
+
+	if shift == 0 {
+		pos = a
+		for _, end := range bucketEnds {
+			if end > pos+1 {
+				sort.Stable(&indexPerm{data: data, keys: keys[pos:end], perm: perm[pos:end]})
+			}
+			pos = end
+		}
+		return
+	}
+
+	nextShift := shift - radix
+	if shift < radix {
+		nextShift = 0
+	}
+	pos = a
+	for _, end := range bucketEnds {
+		if end > pos+1 {
+			radixSortStable(data, keys, perm, nextShift, pos, end, auxKeys, auxPerm, scratch)
+		}
+		pos = end
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2014-6 Randall Farmer. All rights reserved.
+
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package radix
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type stablePair struct {
+	key  int
+	orig int
+}
+
+type stablePairSlice []stablePair
+
+func (p stablePairSlice) Len() int { return len(p) }
+
+// Less always returns false: equal keys must come out in their original
+// order on the strength of the sort's own stability alone, with no help
+// from a tiebreak here. If Less compared orig, an unstable Sort would
+// satisfy checkStable just as well as a stable one, and the test below
+// would prove nothing.
+func (p stablePairSlice) Less(i, j int) bool { return false }
+func (p stablePairSlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p stablePairSlice) Key(i int) Key      { return Key(p[i].key) }
+
+// checkStable fails t unless a is sorted by key and, for each run of equal
+// keys, the elements' original indices are still increasing.
+func checkStable(t *testing.T, a []stablePair) {
+	t.Helper()
+	lastKey, lastOrig := -1, -1
+	for i, p := range a {
+		if p.key < lastKey {
+			t.Fatalf("not sorted at %d: key %d after %d", i, p.key, lastKey)
+		}
+		if p.key == lastKey && p.orig < lastOrig {
+			t.Fatalf("not stable at %d: orig %d came after %d with equal key", i, p.orig, lastOrig)
+		}
+		lastKey, lastOrig = p.key, p.orig
+	}
+}
+
+// TestSortStable uses a small key range so equal keys, and thus stability,
+// are common, over both the small-input sort.Stable path and the
+// radixSortStable path.
+func TestSortStable(t *testing.T) {
+	for _, n := range []int{10, 300, qSortCutoff * 3000} {
+		a := make(stablePairSlice, n)
+		for i := range a {
+			a[i] = stablePair{key: rand.Intn(8), orig: i}
+		}
+		SortStable(a)
+		checkStable(t, a)
+	}
+}
+
+func TestSortIndexStable(t *testing.T) {
+	const n = qSortCutoff * 3000
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = rand.Intn(8)
+	}
+	data := make(stablePairSlice, n)
+	for i := range data {
+		data[i] = stablePair{key: keys[i], orig: i}
+	}
+	SortIndexStable(stablePairIndexBuilder{data})
+	checkStable(t, data)
+}
+
+type stablePairIndexBuilder struct {
+	stablePairSlice
+}
+
+func (b stablePairIndexBuilder) SetKeys(keys []Key, a int) {
+	for i := range keys {
+		if i+a == len(b.stablePairSlice) {
+			break
+		}
+		keys[i] = b.stablePairSlice.Key(i + a)
+	}
+}